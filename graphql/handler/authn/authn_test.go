@@ -0,0 +1,267 @@
+package authn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/executor/testexecutor"
+	"github.com/99designs/gqlgen/graphql/handler/authn"
+)
+
+func TestAuthn(t *testing.T) {
+	t.Run("rejects requests outside the IP allow-list", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(authn.WithAllowedIPs("10.0.0.1")))
+
+		resp := query(exec, "203.0.113.5:1234", "", "{name}")
+		assert.Empty(t, string(resp.Data))
+		assert.Len(t, resp.Errors, 1)
+		assert.Equal(t, errcode.ValidationFailed, resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("allows requests inside a CIDR range", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(authn.WithAllowedIPs("10.0.0.0/8")))
+
+		resp := query(exec, "10.1.2.3:1234", "", "{name}")
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+	})
+
+	t.Run("a client cannot spoof its way past the allow-list with its own X-Forwarded-For", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(authn.WithAllowedIPs("10.0.0.1")))
+
+		resp := queryXFF(exec, "203.0.113.5:1234", "10.0.0.1", "{name}")
+		assert.Empty(t, string(resp.Data), "RemoteAddr is not a trusted proxy, so X-Forwarded-For must be ignored")
+		assert.Len(t, resp.Errors, 1)
+	})
+
+	t.Run("X-Forwarded-For is honored from a trusted proxy", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(
+			authn.WithAllowedIPs("203.0.113.9"),
+			authn.WithTrustedProxies("10.0.0.1"),
+		))
+
+		resp := queryXFF(exec, "10.0.0.1:1234", "203.0.113.9", "{name}")
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+	})
+
+	t.Run("rejects requests with a missing token", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(authn.WithToken("X-Api-Key", "secret")))
+
+		resp := query(exec, "10.0.0.1:1234", "", "{name}")
+		assert.Empty(t, string(resp.Data))
+		assert.Len(t, resp.Errors, 1)
+		assert.Equal(t, errcode.ValidationFailed, resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("runs auth before user AroundOperations", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(authn.New(authn.WithAllowedIPs("10.0.0.1")))
+
+		var calls []string
+		exec.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+			calls = append(calls, "user-middleware")
+			return next(ctx)
+		})
+
+		resp := query(exec, "203.0.113.5:1234", "", "{name}")
+		assert.Empty(t, string(resp.Data))
+		assert.Empty(t, calls, "auth should short-circuit before AroundOperations runs")
+	})
+
+	t.Run("denies a named operation without the required role", func(t *testing.T) {
+		exec := testexecutor.New()
+		denyAll := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return false
+		})
+		exec.Use(authn.New(authn.WithRule(authn.Rule{
+			Operation: "Foo",
+			Role:      denyAll,
+		})))
+
+		resp := query(exec, "10.0.0.1:1234", "Foo", "query Foo {name}")
+		assert.Empty(t, string(resp.Data))
+		assert.Len(t, resp.Errors, 1)
+		assert.Equal(t, errcode.ValidationFailed, resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("allows a named operation outside its rule", func(t *testing.T) {
+		exec := testexecutor.New()
+		denyAll := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return false
+		})
+		exec.Use(authn.New(authn.WithRule(authn.Rule{
+			Operation: "Foo",
+			Role:      denyAll,
+		})))
+
+		resp := query(exec, "10.0.0.1:1234", "Bar", "query Bar {name}")
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+	})
+
+	t.Run("leaves fields without @admin unguarded", func(t *testing.T) {
+		exec := testexecutor.New()
+		guardianOnly := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return ctx.Value(principalKey{}) == "guardian"
+		})
+		exec.Use(authn.New(authn.SchemaDirective("admin", guardianOnly)))
+
+		resp := query(exec, "10.0.0.1:1234", "", "{name}")
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data), "name carries no @admin directive so it is unguarded")
+	})
+
+	t.Run("rejects an @admin field when the principal lacks the role", func(t *testing.T) {
+		guardianOnly := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return ctx.Value(principalKey{}) == "guardian"
+		})
+		a := authn.New(authn.SchemaDirective("admin", guardianOnly))
+		require.NoError(t, a.Validate(adminFieldSchema()))
+
+		opCtx := directSelectionOpCtx(&ast.Field{Name: "secret"})
+		gerr := a.MutateOperationContext(context.Background(), opCtx)
+		require.NotNil(t, gerr)
+		assert.Equal(t, errcode.ValidationFailed, gerr.Extensions["code"])
+	})
+
+	t.Run("allows an @admin field when the principal has the role", func(t *testing.T) {
+		guardianOnly := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return ctx.Value(principalKey{}) == "guardian"
+		})
+		a := authn.New(authn.SchemaDirective("admin", guardianOnly))
+		require.NoError(t, a.Validate(adminFieldSchema()))
+
+		opCtx := directSelectionOpCtx(&ast.Field{Name: "secret"})
+		ctx := context.WithValue(context.Background(), principalKey{}, "guardian")
+		assert.Nil(t, a.MutateOperationContext(ctx, opCtx))
+	})
+
+	t.Run("an @admin field hidden behind a named fragment is still guarded", func(t *testing.T) {
+		guardianOnly := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return false
+		})
+		a := authn.New(authn.SchemaDirective("admin", guardianOnly))
+		require.NoError(t, a.Validate(adminFieldSchema()))
+
+		opCtx := &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{
+				Operation:    ast.Query,
+				SelectionSet: ast.SelectionSet{&ast.FragmentSpread{Name: "Frag"}},
+			},
+			Doc: &ast.QueryDocument{
+				Fragments: ast.FragmentDefinitionList{{
+					Name:         "Frag",
+					SelectionSet: ast.SelectionSet{&ast.Field{Name: "secret"}},
+				}},
+			},
+		}
+
+		gerr := a.MutateOperationContext(context.Background(), opCtx)
+		require.NotNil(t, gerr, "the admin field must not be reachable by hiding it in a fragment spread")
+	})
+
+	t.Run("a per-root-field Rule is enforced through an inline fragment", func(t *testing.T) {
+		denyAll := authn.RoleCheckerFunc(func(ctx context.Context, operationName, field string) bool {
+			return false
+		})
+		a := authn.New(authn.WithRule(authn.Rule{Field: "secret", Role: denyAll}))
+
+		opCtx := &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{
+				Operation: ast.Query,
+				SelectionSet: ast.SelectionSet{&ast.InlineFragment{
+					SelectionSet: ast.SelectionSet{&ast.Field{Name: "secret"}},
+				}},
+			},
+			Doc: &ast.QueryDocument{},
+		}
+
+		gerr := a.MutateOperationContext(context.Background(), opCtx)
+		require.NotNil(t, gerr, "the rule must not be bypassable via an inline fragment")
+	})
+}
+
+type principalKey struct{}
+
+// adminFieldSchema builds a minimal ExecutableSchema whose Query type has
+// one field, "secret", tagged with @admin.
+func adminFieldSchema() graphql.ExecutableSchema {
+	return fakeSchema{
+		schema: &ast.Schema{
+			Query: &ast.Definition{
+				Kind: ast.Object,
+				Name: "Query",
+				Fields: ast.FieldList{
+					{Name: "secret", Directives: ast.DirectiveList{{Name: "admin"}}},
+					{Name: "name"},
+				},
+			},
+		},
+	}
+}
+
+// directSelectionOpCtx builds an OperationContext selecting field directly,
+// with no surrounding fragment.
+func directSelectionOpCtx(field *ast.Field) *graphql.OperationContext {
+	return &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{
+			Operation:    ast.Query,
+			SelectionSet: ast.SelectionSet{field},
+		},
+		Doc: &ast.QueryDocument{},
+	}
+}
+
+type fakeSchema struct {
+	schema *ast.Schema
+}
+
+func (f fakeSchema) Schema() *ast.Schema { return f.schema }
+
+func (f fakeSchema) Complexity(typeName, field string, childComplexity int, rawArgs map[string]interface{}) (int, bool) {
+	return 0, false
+}
+
+func (f fakeSchema) Exec(ctx context.Context) graphql.ResponseHandler {
+	return func(ctx context.Context) *graphql.Response { return &graphql.Response{} }
+}
+
+func query(exec *testexecutor.TestExecutor, remoteAddr, op, q string) *graphql.Response {
+	ctx := authn.WithTransportInfo(context.Background(), authn.TransportInfo{RemoteAddr: remoteAddr})
+	return dispatch(exec, ctx, op, q)
+}
+
+func queryXFF(exec *testexecutor.TestExecutor, remoteAddr, xForwardedFor, q string) *graphql.Response {
+	ctx := authn.WithTransportInfo(context.Background(), authn.TransportInfo{
+		RemoteAddr:    remoteAddr,
+		XForwardedFor: xForwardedFor,
+	})
+	return dispatch(exec, ctx, "", q)
+}
+
+func dispatch(exec *testexecutor.TestExecutor, ctx context.Context, op, q string) *graphql.Response {
+	ctx = graphql.StartOperationTrace(ctx)
+	now := graphql.Now()
+	rc, err := exec.CreateOperationContext(ctx, &graphql.RawParams{
+		Query:         q,
+		OperationName: op,
+		ReadTime: graphql.TraceTiming{
+			Start: now,
+			End:   now,
+		},
+	})
+	if err != nil {
+		return exec.DispatchError(ctx, err)
+	}
+
+	resp, ctx2 := exec.DispatchOperation(ctx, rc)
+	return resp(ctx2)
+}
@@ -0,0 +1,168 @@
+package extension
+
+import (
+	"context"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+)
+
+// AroundComplexityFunc computes the complexity budget for a single
+// operation. It runs after parsing and validation but before dispatch, and
+// the returned limit overrides the global ComplexityLimit for that request.
+// A negative limit means no limit is enforced for this operation; a limit
+// of 0 or more is the maximum complexity allowed, so 0 rejects any
+// operation with a non-zero cost. Returning a non-nil error rejects the
+// operation before it reaches a resolver.
+type AroundComplexityFunc func(ctx context.Context, opCtx *graphql.OperationContext) (limit int, err *gqlerror.Error)
+
+// DynamicComplexityLimit is a graphql.HandlerExtension that, unlike
+// ComplexityLimit, can vary the complexity budget per caller by consulting
+// an AroundComplexityFunc. The computed cost, the limit that was applied,
+// and the most expensive root field paths are stashed on the
+// graphql.OperationContext and published as extensions.complexity so
+// AroundResponses middleware can log or return them.
+type DynamicComplexityLimit struct {
+	limitFunc AroundComplexityFunc
+	topN      int
+	schema    graphql.ExecutableSchema
+}
+
+// NewDynamicComplexityLimit builds a DynamicComplexityLimit extension.
+// topN controls how many of the most expensive root field paths are
+// reported in extensions.complexity.offendingPath; 0 defaults to 3.
+func NewDynamicComplexityLimit(limitFunc AroundComplexityFunc, topN int) *DynamicComplexityLimit {
+	if topN <= 0 {
+		topN = 3
+	}
+	return &DynamicComplexityLimit{limitFunc: limitFunc, topN: topN}
+}
+
+var _ graphql.HandlerExtension = (*DynamicComplexityLimit)(nil)
+var _ graphql.OperationContextMutator = (*DynamicComplexityLimit)(nil)
+
+func (c *DynamicComplexityLimit) ExtensionName() string {
+	return "DynamicComplexityLimit"
+}
+
+// Validate captures the schema so MutateOperationContext can compute field
+// costs; graphql.OperationContext carries no ExecutableSchema of its own.
+func (c *DynamicComplexityLimit) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema
+	return nil
+}
+
+func (c *DynamicComplexityLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	limit, gerr := c.limitFunc(ctx, opCtx)
+	if gerr != nil {
+		return gerr
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	costs := rootFieldCosts(c.schema, opCtx.Operation, opCtx.Variables)
+
+	actual := 0
+	for _, fc := range costs {
+		actual += fc.cost
+	}
+
+	sort.Slice(costs, func(i, j int) bool { return costs[i].cost > costs[j].cost })
+	paths := make([]string, 0, c.topN)
+	for i := 0; i < len(costs) && i < c.topN; i++ {
+		paths = append(paths, costs[i].path)
+	}
+
+	// graphql.OperationContext has no ComplexityLimit/OperationComplexity
+	// fields of its own; stash them, and the offending paths, on Stats the
+	// same way the static extension.ComplexityLimit does, so
+	// AroundResponses middleware can read them back via
+	// opCtx.Stats.GetExtension.
+	opCtx.Stats.SetExtension("complexityLimit", limit)
+	opCtx.Stats.SetExtension("operationComplexity", actual)
+	opCtx.Stats.SetExtension("complexityOffendingPath", paths)
+
+	graphql.RegisterExtension(ctx, "complexity", map[string]interface{}{
+		"limit":         limit,
+		"actual":        actual,
+		"offendingPath": paths,
+	})
+
+	if actual > limit {
+		return &gqlerror.Error{
+			Message: "operation is too complex",
+			Extensions: map[string]interface{}{
+				"code":          errcode.ValidationFailed,
+				"type":          "COMPLEXITY_LIMIT",
+				"limit":         limit,
+				"actual":        actual,
+				"offendingPath": paths,
+			},
+		}
+	}
+
+	return nil
+}
+
+type fieldCost struct {
+	path string
+	cost int
+}
+
+func rootFieldCosts(es graphql.ExecutableSchema, op *ast.OperationDefinition, vars map[string]interface{}) []fieldCost {
+	if op == nil {
+		return nil
+	}
+
+	costs := make([]fieldCost, 0, len(op.SelectionSet))
+	for _, sel := range op.SelectionSet {
+		f, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+
+		path := f.Name
+		if f.Alias != "" && f.Alias != f.Name {
+			path = f.Alias
+		}
+		costs = append(costs, fieldCost{path: path, cost: fieldComplexity(es, rootTypeName(op), f, vars)})
+	}
+	return costs
+}
+
+func fieldComplexity(es graphql.ExecutableSchema, objectName string, f *ast.Field, vars map[string]interface{}) int {
+	child := 0
+	for _, sel := range f.SelectionSet {
+		cf, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+		var childObject string
+		if f.Definition != nil {
+			childObject = f.Definition.Type.Name()
+		}
+		child += fieldComplexity(es, childObject, cf, vars)
+	}
+
+	cost, ok := es.Complexity(objectName, f.Name, child, f.ArgumentMap(vars))
+	if !ok {
+		cost = child + 1
+	}
+	return cost
+}
+
+func rootTypeName(op *ast.OperationDefinition) string {
+	switch op.Operation {
+	case ast.Mutation:
+		return "Mutation"
+	case ast.Subscription:
+		return "Subscription"
+	default:
+		return "Query"
+	}
+}
@@ -0,0 +1,83 @@
+package extension_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor/testexecutor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+type callerTierKey struct{}
+
+func limitForCaller(ctx context.Context, opCtx *graphql.OperationContext) (int, *gqlerror.Error) {
+	tier, _ := ctx.Value(callerTierKey{}).(string)
+	switch tier {
+	case "trusted":
+		return 1000, nil
+	case "unlimited":
+		return -1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func TestDynamicComplexityLimit(t *testing.T) {
+	t.Run("rejects a caller whose budget is below the operation's cost", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(extension.NewDynamicComplexityLimit(limitForCaller, 0))
+
+		ctx := context.WithValue(context.Background(), callerTierKey{}, "anonymous")
+		resp := query(exec, ctx, "{name}")
+
+		assert.Empty(t, string(resp.Data))
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "COMPLEXITY_LIMIT", resp.Errors[0].Extensions["type"])
+		assert.EqualValues(t, 0, resp.Errors[0].Extensions["limit"])
+	})
+
+	t.Run("allows a caller with a generous budget against the same query", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(extension.NewDynamicComplexityLimit(limitForCaller, 0))
+
+		ctx := context.WithValue(context.Background(), callerTierKey{}, "trusted")
+		resp := query(exec, ctx, "{name}")
+
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+		assert.Empty(t, resp.Errors)
+	})
+
+	t.Run("a negative limit disables enforcement entirely", func(t *testing.T) {
+		exec := testexecutor.New()
+		exec.Use(extension.NewDynamicComplexityLimit(limitForCaller, 0))
+
+		ctx := context.WithValue(context.Background(), callerTierKey{}, "unlimited")
+		resp := query(exec, ctx, "{name}")
+
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+		assert.Empty(t, resp.Errors)
+	})
+}
+
+func query(exec *testexecutor.TestExecutor, ctx context.Context, q string) *graphql.Response {
+	ctx = graphql.StartOperationTrace(ctx)
+	now := graphql.Now()
+	rc, err := exec.CreateOperationContext(ctx, &graphql.RawParams{
+		Query: q,
+		ReadTime: graphql.TraceTiming{
+			Start: now,
+			End:   now,
+		},
+	})
+	if err != nil {
+		return exec.DispatchError(ctx, err)
+	}
+
+	resp, ctx2 := exec.DispatchOperation(ctx, rc)
+	return resp(ctx2)
+}
@@ -0,0 +1,51 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// JSONExporter is the built-in SpanExporter: it shapes the span tree into
+// the Apollo tracing v1 format and attaches it to the response as
+// extensions.tracing, the same field Apollo Engine/Studio clients expect.
+type JSONExporter struct{}
+
+var _ SpanExporter = JSONExporter{}
+
+func (JSONExporter) Export(ctx context.Context, opCtx *graphql.OperationContext, spans []Span) {
+	if len(spans) == 0 {
+		return
+	}
+
+	root := spans[0]
+	resolvers := make([]map[string]interface{}, 0, len(spans)-1)
+	for _, s := range spans[1:] {
+		resolver := map[string]interface{}{
+			// Apollo tracing v1 requires path as an array of segments
+			// (e.g. ["hero","name"]), not the joined string form of Path.
+			"path":        s.PathElements,
+			"parentType":  s.ParentType,
+			"fieldName":   s.Field,
+			"returnType":  s.ReturnType,
+			"startOffset": s.Start.Sub(root.Start).Nanoseconds(),
+			"duration":    s.End.Sub(s.Start).Nanoseconds(),
+		}
+		if s.Error != nil {
+			resolver["error"] = s.Error.Error()
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
+	tracing := map[string]interface{}{
+		"version":   1,
+		"startTime": root.Start.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"endTime":   root.End.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"duration":  root.End.Sub(root.Start).Nanoseconds(),
+		"execution": map[string]interface{}{
+			"resolvers": resolvers,
+		},
+	}
+
+	graphql.RegisterExtension(ctx, "tracing", tracing)
+}
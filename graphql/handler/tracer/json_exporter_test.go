@@ -0,0 +1,52 @@
+package tracer_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/tracer"
+)
+
+func TestJSONExporter(t *testing.T) {
+	ctx := graphql.StartOperationTrace(context.Background())
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, nil)
+	now := time.Now()
+
+	spans := []tracer.Span{
+		{Start: now, End: now.Add(5 * time.Millisecond)},
+		{
+			Path:         "name",
+			PathElements: ast.Path{ast.PathName("name")},
+			ParentType:   "Query",
+			Field:        "name",
+			Alias:        "aliasedName",
+			ReturnType:   "String!",
+			Start:        now,
+			End:          now.Add(time.Millisecond),
+		},
+	}
+
+	tracer.JSONExporter{}.Export(ctx, &graphql.OperationContext{}, spans)
+
+	ext := graphql.GetExtensions(ctx)
+	tracing, ok := ext["tracing"].(map[string]interface{})
+	require.True(t, ok)
+
+	execution := tracing["execution"].(map[string]interface{})
+	resolvers := execution["resolvers"].([]map[string]interface{})
+	require.Len(t, resolvers, 1)
+
+	assert.Equal(t, "String!", resolvers[0]["returnType"], "returnType must be the GraphQL type, not the field alias")
+	assert.Equal(t, "Query", resolvers[0]["parentType"], "parentType must be the GraphQL object type, not the resolver path")
+
+	pathJSON, err := json.Marshal(resolvers[0]["path"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `["name"]`, string(pathJSON), "path must be a JSON array of path segments per the Apollo tracing v1 spec, not a joined string")
+}
@@ -0,0 +1,60 @@
+package tracer
+
+import (
+	"context"
+	"sort"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// SpanStarter is the minimal shape this package needs from a tracer to
+// export spans to a tracing backend such as OpenTelemetry. Callers wrap
+// their real tracer (e.g. go.opentelemetry.io/otel/trace.Tracer) in a few
+// lines implementing this interface, so the tracer package itself never
+// needs OpenTelemetry as a dependency.
+type SpanStarter interface {
+	// StartSpan starts a span named name, as a child of the span carried
+	// on ctx (if any), and returns the child ctx plus a func that ends
+	// the span, recording err if it is non-nil.
+	StartSpan(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// OTelExporter adapts a SpanStarter to SpanExporter, replaying the
+// recorded span tree parent-first so each span is started as a child of
+// its parent's context. Because the tree is exported only once the whole
+// operation has finished, the backend will record its own export-time
+// timestamps rather than the original resolution timestamps; callers who
+// need live, in-flight OTel spans should start and end them directly from
+// a Redactor-like hook instead of via this exporter.
+type OTelExporter struct {
+	Starter SpanStarter
+}
+
+var _ SpanExporter = OTelExporter{}
+
+func (e OTelExporter) Export(ctx context.Context, opCtx *graphql.OperationContext, spans []Span) {
+	if e.Starter == nil || len(spans) == 0 {
+		return
+	}
+
+	ordered := make([]Span, len(spans))
+	copy(ordered, spans)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Start.Before(ordered[j].Start) })
+
+	ctxByPath := map[string]context.Context{"": ctx}
+	for _, s := range ordered {
+		parentCtx, ok := ctxByPath[s.ParentPath]
+		if !ok {
+			parentCtx = ctx
+		}
+
+		name := s.Field
+		if name == "" {
+			name = "operation"
+		}
+
+		spanCtx, end := e.Starter.StartSpan(parentCtx, name)
+		ctxByPath[s.Path] = spanCtx
+		end(s.Error)
+	}
+}
@@ -0,0 +1,230 @@
+// Package tracer hooks the AroundOperations, AroundResponses, and
+// AroundFields middleware layers graphql.Executor exposes, plus
+// parse/validate, and turns them into a tree of timed spans keyed by
+// resolver path. Every root selection is itself resolved through
+// AroundFields, so a single FieldInterceptor already times root fields and
+// nested fields alike; a separate root-field interceptor would only
+// double-record the same span. Spans are handed to a pluggable
+// SpanExporter so callers can opt into OpenTelemetry, the built-in
+// Apollo-tracing-v1 JSON shape, or a format of their own, without this
+// package needing OpenTelemetry as a dependency.
+package tracer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Redactor scrubs a field's raw arguments before they are attached to a
+// span, e.g. to drop passwords or tokens from exported traces. The
+// original argument map is left untouched; Redactor returns the map that
+// should be recorded.
+type Redactor func(path string, args map[string]interface{}) map[string]interface{}
+
+// Span is a single timed unit of work: one field resolution, or the
+// operation as a whole (ParentPath and Field both empty).
+type Span struct {
+	Path       string
+	ParentPath string
+	// PathElements is the same path as Path, kept unflattened so exporters
+	// that need per-segment structure (e.g. the Apollo tracing v1 "path"
+	// array) don't have to re-split the joined string.
+	PathElements ast.Path
+	Field        string
+	Alias        string
+	// ParentType is the GraphQL object type the field belongs to, e.g.
+	// "Query"; ReturnType is the field's declared GraphQL return type,
+	// e.g. "String!". Both are type names, not resolver paths.
+	ParentType string
+	ReturnType string
+	Args       map[string]interface{}
+	Start      time.Time
+	End        time.Time
+	Error      error
+}
+
+// SpanExporter receives the finished span tree for a single operation.
+// Implementations should return quickly; a slow exporter should hand off
+// to a background worker itself.
+type SpanExporter interface {
+	Export(ctx context.Context, opCtx *graphql.OperationContext, spans []Span)
+}
+
+// Tracer is a graphql.HandlerExtension that records a span per resolver
+// invocation (plus one span for the operation as a whole) and hands the
+// finished tree to Exporter.
+type Tracer struct {
+	Exporter SpanExporter
+	Redact   Redactor
+}
+
+var (
+	_ graphql.HandlerExtension     = (*Tracer)(nil)
+	_ graphql.OperationInterceptor = (*Tracer)(nil)
+	_ graphql.ResponseInterceptor  = (*Tracer)(nil)
+	_ graphql.FieldInterceptor     = (*Tracer)(nil)
+)
+
+func (Tracer) ExtensionName() string {
+	return "Tracer"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type spanCollector struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+func (c *spanCollector) add(s Span) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.spans = append(c.spans, s)
+}
+
+func (c *spanCollector) list() []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Span, len(c.spans))
+	copy(out, c.spans)
+	return out
+}
+
+type spanCollectorKey struct{}
+
+func collectorFromContext(ctx context.Context) *spanCollector {
+	c, _ := ctx.Value(spanCollectorKey{}).(*spanCollector)
+	return c
+}
+
+// InterceptOperation starts the root span covering parse, validate, and
+// every field resolution for the operation.
+func (t Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	collector := &spanCollector{}
+	ctx = context.WithValue(ctx, spanCollectorKey{}, collector)
+
+	// Parsing and validation have already run by the time operation
+	// middleware is invoked, so start the root span at the operation's
+	// recorded start rather than now.
+	start := time.Now()
+	if opStart := graphql.GetOperationContext(ctx).Stats.OperationStart; !opStart.IsZero() {
+		start = opStart
+	}
+	respHandler := next(ctx)
+
+	return func(ctx context.Context) *graphql.Response {
+		resp := respHandler(ctx)
+
+		root := Span{Start: start, End: time.Now()}
+		errs := graphql.GetErrors(ctx)
+		if len(errs) > 0 && rootSpanOwnsError(errs) {
+			root.Error = errs
+		}
+
+		spans := append([]Span{root}, collector.list()...)
+		attachErrors(spans, errs)
+
+		if t.Exporter != nil {
+			if opCtx := graphql.GetOperationContext(ctx); opCtx != nil {
+				t.Exporter.Export(ctx, opCtx, spans)
+			}
+		}
+
+		return resp
+	}
+}
+
+// InterceptResponse is a no-op placeholder kept so Tracer also satisfies
+// graphql.ResponseInterceptor; span export happens once the whole
+// operation finishes, in InterceptOperation.
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	return next(ctx)
+}
+
+// InterceptField records one span per resolved field.
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	collector := collectorFromContext(ctx)
+	fc := graphql.GetFieldContext(ctx)
+	start := time.Now()
+
+	res, err := next(ctx)
+
+	if collector != nil && fc != nil {
+		collector.add(t.buildSpan(fc, start, time.Now(), err))
+	}
+
+	return res, err
+}
+
+func (t Tracer) buildSpan(fc *graphql.FieldContext, start, end time.Time, err error) Span {
+	path := fc.Path().String()
+	var parentPath string
+	if fc.Parent != nil {
+		parentPath = fc.Parent.Path().String()
+	}
+
+	args := fc.Args
+	if t.Redact != nil {
+		args = t.Redact(path, args)
+	}
+
+	var parentType, returnType string
+	if fc.Field.ObjectDefinition != nil {
+		parentType = fc.Field.ObjectDefinition.Name
+	}
+	if fc.Field.Definition != nil {
+		returnType = fc.Field.Definition.Type.String()
+	}
+
+	return Span{
+		Path:         path,
+		ParentPath:   parentPath,
+		PathElements: fc.Path(),
+		Field:        fc.Field.Name,
+		Alias:        fc.Field.Alias,
+		ParentType:   parentType,
+		ReturnType:   returnType,
+		Args:         args,
+		Start:        start,
+		End:          end,
+		Error:        err,
+	}
+}
+
+// rootSpanOwnsError reports whether any collected error has no path,
+// meaning it belongs to the operation itself (e.g. a parse or validation
+// failure) rather than to a specific field.
+func rootSpanOwnsError(errs gqlerror.List) bool {
+	for _, e := range errs {
+		if len(e.Path) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// attachErrors assigns each collected error to the span whose path matches
+// it, so errors surface on the resolver that produced them rather than
+// always on the root span.
+func attachErrors(spans []Span, errs gqlerror.List) {
+	for _, e := range errs {
+		if len(e.Path) == 0 {
+			continue
+		}
+		path := e.Path.String()
+		for i := range spans {
+			if spans[i].Path == path {
+				spans[i].Error = e
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tracer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor/testexecutor"
+	"github.com/99designs/gqlgen/graphql/handler/tracer"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []tracer.Span
+}
+
+func (e *recordingExporter) Export(ctx context.Context, opCtx *graphql.OperationContext, spans []tracer.Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+}
+
+func TestTracer(t *testing.T) {
+	t.Run("span ordering matches the middleware invocation order", func(t *testing.T) {
+		exec := testexecutor.New()
+		exporter := &recordingExporter{}
+		exec.Use(tracer.Tracer{Exporter: exporter})
+
+		var calls []string
+		exec.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+			calls = append(calls, "operation")
+			return next(ctx)
+		})
+		exec.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+			calls = append(calls, "field")
+			return next(ctx)
+		})
+
+		resp := query(exec, "{name}")
+		assert.JSONEq(t, `{"name":"test"}`, string(resp.Data))
+		assert.Equal(t, []string{"operation", "field"}, calls)
+
+		require.Len(t, exporter.spans, 2, "one span for the operation, one for the name field; AroundFields alone covers root fields so there is no duplicate root-field span")
+		assert.Empty(t, exporter.spans[0].Field, "the first span is the operation-level span")
+		assert.Equal(t, "name", exporter.spans[1].Field)
+		assert.Equal(t, "Query", exporter.spans[1].ParentType)
+		assert.NotEmpty(t, exporter.spans[1].ReturnType, "the JSON exporter's returnType must be a GraphQL type name, not the field alias")
+	})
+
+	t.Run("resolver errors are attached to their own span, not the root", func(t *testing.T) {
+		exec := testexecutor.NewError()
+		exporter := &recordingExporter{}
+		exec.Use(tracer.Tracer{Exporter: exporter})
+
+		resp := query(exec, "{name}")
+		assert.Equal(t, "null", string(resp.Data))
+		require.Len(t, resp.Errors, 1)
+
+		require.Len(t, exporter.spans, 2)
+		assert.Nil(t, exporter.spans[0].Error, "the root span should not carry the field's error")
+		assert.NotNil(t, exporter.spans[1].Error, "the name field's span should carry its resolver error")
+	})
+
+	t.Run("Redactor scrubs arguments before they reach the exporter", func(t *testing.T) {
+		exec := testexecutor.New()
+		exporter := &recordingExporter{}
+		exec.Use(tracer.Tracer{
+			Exporter: exporter,
+			Redact: func(path string, args map[string]interface{}) map[string]interface{} {
+				return map[string]interface{}{"redacted": true}
+			},
+		})
+
+		query(exec, "{name}")
+
+		require.Len(t, exporter.spans, 2)
+		assert.Equal(t, map[string]interface{}{"redacted": true}, exporter.spans[1].Args)
+	})
+}
+
+func query(exec *testexecutor.TestExecutor, q string) *graphql.Response {
+	ctx := graphql.StartOperationTrace(context.Background())
+	now := graphql.Now()
+	rc, err := exec.CreateOperationContext(ctx, &graphql.RawParams{
+		Query: q,
+		ReadTime: graphql.TraceTiming{
+			Start: now,
+			End:   now,
+		},
+	})
+	if err != nil {
+		return exec.DispatchError(ctx, err)
+	}
+
+	resp, ctx2 := exec.DispatchOperation(ctx, rc)
+	return resp(ctx2)
+}
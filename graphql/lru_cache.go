@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// CacheKeyFunc derives the cache key used to store and look up a query.
+type CacheKeyFunc func(query string) string
+
+// Sha256CacheKey hashes the query with sha256, matching Apollo Automatic
+// Persisted Query hashes so that, once a query has been warmed, clients
+// can send only the hash instead of the full query text.
+func Sha256CacheKey(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheStats is a point-in-time snapshot of an LRUCache's counters.
+// Evictions counts entries dropped to make room for a new one; Expirations
+// counts entries dropped because their TTL had passed. A TTL-expired entry
+// is never also counted as an eviction, even though both are implemented
+// as a removal from the underlying LRU.
+type CacheStats struct {
+	Size        int
+	Hits        int64
+	Misses      int64
+	Evictions   int64
+	Expirations int64
+}
+
+type lruEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// LRUCache is a Cache[T] backed by github.com/hashicorp/golang-lru/v2, with
+// a fixed capacity, an optional per-entry TTL, and atomic hit/miss/eviction
+// counters exposed via Stats.
+type LRUCache[T any] struct {
+	cache   *lru.Cache[string, lruEntry[T]]
+	ttl     time.Duration
+	keyFunc CacheKeyFunc
+	report  bool
+
+	hits        atomic.Int64
+	misses      atomic.Int64
+	evictions   atomic.Int64
+	expirations atomic.Int64
+
+	// expiringKeys marks the keys currently being removed by GetByKey's
+	// TTL check, so the eviction callback below can tell a TTL expiry
+	// apart from a capacity-driven eviction and count it separately.
+	expiringKeys sync.Map
+}
+
+// LRUCacheOption configures an LRUCache built by NewLRUCache.
+type LRUCacheOption[T any] func(*LRUCache[T])
+
+// WithTTL expires entries ttl after they were added, independent of how
+// recently they were used.
+func WithTTL[T any](ttl time.Duration) LRUCacheOption[T] {
+	return func(c *LRUCache[T]) { c.ttl = ttl }
+}
+
+// WithCacheKeyFunc keys entries on keyFunc(query) rather than the raw query
+// string, e.g. Sha256CacheKey to match Apollo APQ hashes.
+func WithCacheKeyFunc[T any](keyFunc CacheKeyFunc) LRUCacheOption[T] {
+	return func(c *LRUCache[T]) { c.keyFunc = keyFunc }
+}
+
+// WithStatsExtension makes the cache append an `extensions.queryCache`
+// entry (`{hit, size, hits, misses}`) to every operation's response,
+// without requiring a separate handler extension to be registered.
+func WithStatsExtension[T any]() LRUCacheOption[T] {
+	return func(c *LRUCache[T]) { c.report = true }
+}
+
+// NewLRUCache builds an LRUCache that holds at most size entries.
+func NewLRUCache[T any](size int, opts ...LRUCacheOption[T]) *LRUCache[T] {
+	c := &LRUCache[T]{keyFunc: func(query string) string { return query }}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if size <= 0 {
+		size = 1
+	}
+	// The error return is only non-nil for a non-positive size, which is
+	// already guarded above.
+	c.cache, _ = lru.NewWithEvict[string, lruEntry[T]](size, func(key string, _ lruEntry[T]) {
+		if _, expiring := c.expiringKeys.Load(key); expiring {
+			c.expirations.Add(1)
+			return
+		}
+		c.evictions.Add(1)
+	})
+	return c
+}
+
+var _ Cache[any] = (*LRUCache[any])(nil)
+
+// Get implements Cache[T]: it hashes query through the configured
+// CacheKeyFunc before looking it up. Callers that already have the
+// computed key (e.g. a client-supplied APQ hash) must use GetByKey
+// instead, or Get would hash the hash again and never find it.
+func (c *LRUCache[T]) Get(ctx context.Context, query string) (T, bool) {
+	return c.GetByKey(ctx, c.keyFunc(query))
+}
+
+// Add implements Cache[T]: it hashes query through the configured
+// CacheKeyFunc before storing it, the counterpart to Get.
+func (c *LRUCache[T]) Add(ctx context.Context, query string, value T) {
+	c.AddByKey(c.keyFunc(query), value)
+}
+
+// GetByKey looks up value by the already-computed cache key, bypassing
+// CacheKeyFunc. This is how a client-supplied APQ hash should be looked
+// up: it is already the key WarmUp (via Add) stored it under.
+func (c *LRUCache[T]) GetByKey(ctx context.Context, key string) (T, bool) {
+	entry, ok := c.cache.Get(key)
+	if ok && c.ttl > 0 && Now().After(entry.expiresAt) {
+		c.expiringKeys.Store(key, struct{}{})
+		c.cache.Remove(key)
+		c.expiringKeys.Delete(key)
+		ok = false
+	}
+
+	if !ok {
+		c.misses.Add(1)
+		if c.report {
+			c.registerStats(ctx, false)
+		}
+		var zero T
+		return zero, false
+	}
+
+	c.hits.Add(1)
+	if c.report {
+		c.registerStats(ctx, true)
+	}
+	return entry.value, true
+}
+
+// AddByKey stores value under the already-computed cache key, bypassing
+// CacheKeyFunc.
+func (c *LRUCache[T]) AddByKey(key string, value T) {
+	entry := lruEntry[T]{value: value}
+	if c.ttl > 0 {
+		entry.expiresAt = Now().Add(c.ttl)
+	}
+	c.cache.Add(key, entry)
+}
+
+// Stats reports the cache's current size and cumulative hit/miss/
+// eviction/expiration counts.
+func (c *LRUCache[T]) Stats() CacheStats {
+	return CacheStats{
+		Size:        c.cache.Len(),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+// WarmUp pre-parses and inserts queries into the cache ahead of traffic, so
+// operators can guarantee a warm cache for a known set of persisted
+// queries at server start. parse is typically parser.ParseQuery when T is
+// *ast.QueryDocument. Each query is stored under CacheKeyFunc(query), so
+// with WithCacheKeyFunc(Sha256CacheKey) a client can send just the hash
+// afterwards and look it up directly with GetByKey.
+func (c *LRUCache[T]) WarmUp(ctx context.Context, queries []string, parse func(query string) (T, error)) error {
+	for _, query := range queries {
+		value, err := parse(query)
+		if err != nil {
+			return fmt.Errorf("warming up query cache: %w", err)
+		}
+		c.Add(ctx, query, value)
+	}
+	return nil
+}
+
+func (c *LRUCache[T]) registerStats(ctx context.Context, hit bool) {
+	stats := c.Stats()
+	RegisterExtension(ctx, "queryCache", map[string]interface{}{
+		"hit":    hit,
+		"size":   stats.Size,
+		"hits":   stats.Hits,
+		"misses": stats.Misses,
+	})
+}
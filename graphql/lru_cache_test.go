@@ -0,0 +1,96 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cache miss then hit", func(t *testing.T) {
+		cache := graphql.NewLRUCache[string](10)
+
+		_, ok := cache.Get(ctx, "query Foo {name}")
+		assert.False(t, ok)
+
+		cache.Add(ctx, "query Foo {name}", "doc-foo")
+
+		value, ok := cache.Get(ctx, "query Foo {name}")
+		require.True(t, ok)
+		assert.Equal(t, "doc-foo", value)
+
+		stats := cache.Stats()
+		assert.Equal(t, 1, stats.Size)
+		assert.EqualValues(t, 1, stats.Hits)
+		assert.EqualValues(t, 1, stats.Misses)
+	})
+
+	t.Run("evicts the least recently used entry once capacity is exceeded", func(t *testing.T) {
+		cache := graphql.NewLRUCache[string](2)
+
+		cache.Add(ctx, "a", "a-doc")
+		cache.Add(ctx, "b", "b-doc")
+		cache.Add(ctx, "c", "c-doc")
+
+		_, ok := cache.Get(ctx, "a")
+		assert.False(t, ok, "a should have been evicted to make room for c")
+
+		stats := cache.Stats()
+		assert.Equal(t, 2, stats.Size)
+		assert.EqualValues(t, 1, stats.Evictions)
+	})
+
+	t.Run("expires entries once their TTL elapses", func(t *testing.T) {
+		cache := graphql.NewLRUCache[string](10, graphql.WithTTL[string](10*time.Millisecond))
+
+		cache.Add(ctx, "a", "a-doc")
+		_, ok := cache.Get(ctx, "a")
+		require.True(t, ok)
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, ok = cache.Get(ctx, "a")
+		assert.False(t, ok, "entry should have expired")
+
+		stats := cache.Stats()
+		assert.EqualValues(t, 1, stats.Expirations, "a TTL expiry must not also be counted as an eviction")
+		assert.EqualValues(t, 0, stats.Evictions)
+	})
+
+	t.Run("keys on a CacheKeyFunc instead of the raw query", func(t *testing.T) {
+		cache := graphql.NewLRUCache[string](10, graphql.WithCacheKeyFunc[string](graphql.Sha256CacheKey))
+
+		cache.Add(ctx, "query Foo {name}", "doc-foo")
+
+		value, ok := cache.Get(ctx, "query Foo {name}")
+		require.True(t, ok)
+		assert.Equal(t, "doc-foo", value)
+
+		value, ok = cache.GetByKey(ctx, graphql.Sha256CacheKey("query Foo {name}"))
+		require.True(t, ok, "a client sending the raw hash should look it up by key directly, not re-hash it")
+		assert.Equal(t, "doc-foo", value)
+
+		_, ok = cache.Get(ctx, graphql.Sha256CacheKey("query Foo {name}"))
+		assert.False(t, ok, "Get always applies CacheKeyFunc, so passing an already-computed hash to it must not match")
+	})
+
+	t.Run("WarmUp pre-populates the cache using the given parse func", func(t *testing.T) {
+		cache := graphql.NewLRUCache[string](10)
+
+		err := cache.WarmUp(ctx, []string{"query Foo {name}"}, func(query string) (string, error) {
+			return "parsed:" + query, nil
+		})
+		require.NoError(t, err)
+
+		value, ok := cache.Get(ctx, "query Foo {name}")
+		require.True(t, ok)
+		assert.Equal(t, "parsed:query Foo {name}", value)
+	})
+}